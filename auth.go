@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role is a permission level granted to a user. Roles are ordered: a
+// contributor can do everything a reader can, and an admin everything a
+// contributor can.
+type Role string
+
+const (
+	RoleReader      Role = "reader"
+	RoleContributor Role = "contributor"
+	RoleAdmin       Role = "admin"
+)
+
+var roleRank = map[Role]int{RoleReader: 0, RoleContributor: 1, RoleAdmin: 2}
+
+func (r Role) atLeast(min Role) bool { return roleRank[r] >= roleRank[min] }
+
+// User is an API principal. Either the API key or username/password can be
+// used to authenticate as them.
+type User struct {
+	Username     string
+	PasswordHash []byte
+	APIKey       string
+	Role         Role
+}
+
+// UserStore is a threadsafe in-memory registry of API users. Unlike Store it
+// has no persistent backend: credentials are provisioned per-deployment via
+// bootstrapAdmin, not created through the API.
+type UserStore struct {
+	mu     sync.RWMutex
+	byName map[string]*User
+	byKey  map[string]*User
+}
+
+func NewUserStore() *UserStore {
+	return &UserStore{byName: make(map[string]*User), byKey: make(map[string]*User)}
+}
+
+// Put inserts or replaces a user, indexing it by both username and API key.
+func (s *UserStore) Put(u *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byName[u.Username] = u
+	if u.APIKey != "" {
+		s.byKey[u.APIKey] = u
+	}
+}
+
+func (s *UserStore) ByUsername(name string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.byName[name]
+	return u, ok
+}
+
+func (s *UserStore) ByAPIKey(key string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.byKey[key]
+	return u, ok
+}
+
+// bootstrapAdmin ensures an admin user exists, provisioning one from
+// JOKE_ADMIN_USER/JOKE_ADMIN_PASSWORD the first time the store has none.
+// If JOKE_ADMIN_PASSWORD isn't set, a password is generated and logged once.
+func bootstrapAdmin(users *UserStore) error {
+	username := envOr("JOKE_ADMIN_USER", "admin")
+	if _, ok := users.ByUsername(username); ok {
+		return nil
+	}
+
+	password := os.Getenv("JOKE_ADMIN_PASSWORD")
+	generated := password == ""
+	if generated {
+		password = randomToken(12)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("auth: bootstrap admin: %w", err)
+	}
+
+	users.Put(&User{
+		Username:     username,
+		PasswordHash: hash,
+		APIKey:       randomToken(24),
+		Role:         RoleAdmin,
+	})
+	if generated {
+		log.Printf("bootstrapped admin user %q with generated password %q (set JOKE_ADMIN_PASSWORD to override)", username, password)
+	}
+	return nil
+}
+
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// jwtClaims is embedded in every token minted by authenticator.mintToken.
+type jwtClaims struct {
+	Role Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// authenticator verifies API keys and JWTs against a UserStore.
+type authenticator struct {
+	users  *UserStore
+	secret []byte
+}
+
+func newAuthenticator(users *UserStore, secret []byte) *authenticator {
+	return &authenticator{users: users, secret: secret}
+}
+
+// mintToken signs a JWT asserting u's identity and role, valid for 24h.
+func (a *authenticator) mintToken(u *User) (string, time.Time, error) {
+	expiresAt := time.Now().Add(24 * time.Hour)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+		Role: u.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   u.Username,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	signed, err := token.SignedString(a.secret)
+	return signed, expiresAt, err
+}
+
+// authenticate resolves the Authorization header to a User. The bearer
+// value is tried first as a raw API key, then as a signed JWT.
+func (a *authenticator) authenticate(r *http.Request) (*User, error) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if header == "" {
+		return nil, ErrUnauthorized
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	if u, ok := a.users.ByAPIKey(token); ok {
+		return u, nil
+	}
+
+	var claims jwtClaims
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return a.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, ErrUnauthorized
+	}
+	u, ok := a.users.ByUsername(claims.Subject)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	return u, nil
+}
+
+// requireRole wraps h so it fails with ErrUnauthorized on missing/invalid
+// credentials and ErrForbidden when the caller's role doesn't meet min.
+func (a *authenticator) requireRole(min Role, h handlerFunc) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		u, err := a.authenticate(r)
+		if err != nil {
+			return err
+		}
+		if !u.Role.atLeast(min) {
+			return ErrForbidden
+		}
+		return h(w, r)
+	}
+}
+
+// POST /auth/token { "username": "...", "password": "..." } -> JWT
+func (a *authenticator) token(w http.ResponseWriter, r *http.Request) error {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return ErrValidation{Field: "body", Msg: "invalid json"}
+	}
+	u, ok := a.users.ByUsername(req.Username)
+	if !ok || bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(req.Password)) != nil {
+		return ErrUnauthorized
+	}
+	signed, expiresAt, err := a.mintToken(u)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusOK, struct {
+		Token     string    `json:"token" xml:"token" yaml:"token"`
+		ExpiresAt time.Time `json:"expires_at" xml:"expires_at" yaml:"expires_at"`
+	}{signed, expiresAt})
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ErrMethodNotAllowed is returned by handlers when the request method isn't
+// supported on that route.
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// ErrUnauthorized is returned when a request has no, or invalid, credentials.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden is returned when a caller is authenticated but lacks the
+// role required for the action.
+var ErrForbidden = errors.New("forbidden")
+
+// handlerFunc is like http.HandlerFunc but returns an error instead of
+// writing one directly, so every handler renders errors the same way.
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ErrValidation reports a bad request field. Handlers return it directly;
+// Handle renders it as a 400 with the field name in Details.
+type ErrValidation struct {
+	Field string
+	Msg   string
+}
+
+func (e ErrValidation) Error() string { return fmt.Sprintf("%s: %s", e.Field, e.Msg) }
+
+// errorBody is the JSON/XML/YAML/text shape written for every handler
+// error, success responses use the bare value instead.
+type errorBody struct {
+	XMLName   xml.Name `json:"-" xml:"error" yaml:"-"`
+	Status    int      `json:"status" xml:"status" yaml:"status"`
+	Error     string   `json:"error" xml:"error" yaml:"error"`
+	Message   string   `json:"message" xml:"message" yaml:"message"`
+	Details   string   `json:"details,omitempty" xml:"details,omitempty" yaml:"details,omitempty"`
+	Code      string   `json:"code" xml:"code" yaml:"code"`
+	RequestID string   `json:"request_id" xml:"request_id" yaml:"request_id"`
+}
+
+// Handle adapts a handlerFunc to http.HandlerFunc: it recovers panics into
+// 500 responses (logging the stack, never returning it) and renders any
+// returned error as a structured errorBody.
+func Handle(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := middleware.GetReqID(r.Context())
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("panic handling %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				writeResponse(w, r, http.StatusInternalServerError, errorBody{
+					Status:    http.StatusInternalServerError,
+					Error:     "internal_error",
+					Message:   "internal server error",
+					Code:      "internal_error",
+					RequestID: requestID,
+				})
+			}
+		}()
+		if err := h(w, r); err != nil {
+			renderError(w, r, requestID, err)
+		}
+	}
+}
+
+// renderError maps err to a status/code and writes it as an errorBody.
+func renderError(w http.ResponseWriter, r *http.Request, requestID string, err error) {
+	status, code, details := http.StatusInternalServerError, "internal_error", ""
+
+	var verr ErrValidation
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status, code = http.StatusNotFound, "not_found"
+	case errors.Is(err, ErrMethodNotAllowed):
+		status, code = http.StatusMethodNotAllowed, "method_not_allowed"
+	case errors.Is(err, ErrUnauthorized):
+		status, code = http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, ErrForbidden):
+		status, code = http.StatusForbidden, "forbidden"
+	case errors.As(err, &verr):
+		status, code = http.StatusBadRequest, "validation_error"
+		details = verr.Field
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		log.Printf("error handling %s %s: %v", r.Method, r.URL.Path, err)
+		message = "internal server error"
+	}
+
+	writeResponse(w, r, status, errorBody{
+		Status:    status,
+		Error:     code,
+		Message:   message,
+		Details:   details,
+		Code:      code,
+		RequestID: requestID,
+	})
+}
+
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
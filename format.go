@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jokeList is the XML/YAML root element for a slice of jokes; both formats
+// need a named wrapper where JSON is happy with a bare array.
+type jokeList struct {
+	XMLName xml.Name `xml:"jokes" yaml:"-"`
+	Jokes   []*Joke  `xml:"joke" yaml:"jokes"`
+}
+
+// writeResponse encodes v as JSON, XML, YAML or plain text depending on the
+// ?format= query param (if set) or the request's Accept header, defaulting
+// to JSON. The API handlers and renderError write their response bodies
+// through it.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, v any) {
+	switch negotiateFormat(r) {
+	case "xml":
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(xml.Header))
+		_ = xml.NewEncoder(w).Encode(wrapForXMLOrYAML(v))
+	case "yaml":
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(status)
+		_ = yaml.NewEncoder(w).Encode(wrapForXMLOrYAML(v))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(asPlainText(v)))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+// negotiateFormat picks a response format from ?format= (if present and
+// recognized) or the Accept header, falling back to json. Accept entries are
+// ranked by their "q" weight (default 1), highest first, so e.g.
+// "application/xml;q=0.1, application/json;q=0.9" resolves to json rather
+// than whichever type happened to be listed first.
+func negotiateFormat(r *http.Request) string {
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		switch f {
+		case "xml", "yaml", "text", "json":
+			return f
+		}
+	}
+
+	best, bestQ := "", -1.0
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		format, ok := formatForMediaType(mt)
+		if !ok {
+			continue
+		}
+		q := acceptQuality(params)
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return "json"
+}
+
+// formatForMediaType maps an Accept media type to the response format that
+// serves it, if any.
+func formatForMediaType(mt string) (string, bool) {
+	switch mt {
+	case "application/xml", "text/xml":
+		return "xml", true
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return "yaml", true
+	case "text/plain":
+		return "text", true
+	case "application/json", "*/*":
+		return "json", true
+	}
+	return "", false
+}
+
+// acceptQuality reads the "q" parameter from a parsed Accept entry,
+// defaulting to 1 (the highest weight) when absent or unparseable.
+func acceptQuality(params map[string]string) float64 {
+	v, ok := params["q"]
+	if !ok {
+		return 1
+	}
+	q, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 1
+	}
+	return q
+}
+
+// wrapForXMLOrYAML gives a []*Joke a named root element; any other value
+// (a single *Joke, an error body, ...) is passed through unchanged.
+func wrapForXMLOrYAML(v any) any {
+	if jokes, ok := v.([]*Joke); ok {
+		return jokeList{Jokes: jokes}
+	}
+	return v
+}
+
+func asPlainText(v any) string {
+	switch val := v.(type) {
+	case []*Joke:
+		var b strings.Builder
+		for _, j := range val {
+			fmt.Fprintf(&b, "#%d: %s (%s)\n", j.ID, j.Content, authorOrUnknown(j.Author))
+		}
+		return b.String()
+	case *Joke:
+		return fmt.Sprintf("#%d: %s (%s)\n", val.ID, val.Content, authorOrUnknown(val.Author))
+	default:
+		b, _ := json.Marshal(val)
+		return string(b) + "\n"
+	}
+}
+
+func authorOrUnknown(author string) string {
+	if author == "" {
+		return "unknown"
+	}
+	return author
+}
+
+// jokeRequest is the payload accepted by POST /jokes in any supported
+// encoding.
+type jokeRequest struct {
+	XMLName  xml.Name `xml:"joke" json:"-" yaml:"-"`
+	Content  string   `json:"content" xml:"content" yaml:"content"`
+	Author   string   `json:"author,omitempty" xml:"author,omitempty" yaml:"author,omitempty"`
+	Category string   `json:"category,omitempty" xml:"category,omitempty" yaml:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty" xml:"tags>tag,omitempty" yaml:"tags,omitempty"`
+}
+
+// decodeRequest reads req into a jokeRequest based on Content-Type, mirroring
+// how Echo's DefaultBinder dispatches on content type: JSON, XML and
+// form-encoded bodies are all accepted.
+func decodeRequest(r *http.Request) (jokeRequest, error) {
+	var req jokeRequest
+
+	contentType := r.Header.Get("Content-Type")
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = "application/json"
+	}
+
+	switch mt {
+	case "application/xml", "text/xml":
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("invalid xml: %w", err)
+		}
+	case "application/x-www-form-urlencoded":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return req, fmt.Errorf("invalid form body: %w", err)
+		}
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return req, fmt.Errorf("invalid form body: %w", err)
+		}
+		req.Content = values.Get("content")
+		req.Author = values.Get("author")
+		req.Category = values.Get("category")
+		if tags := values.Get("tags"); tags != "" {
+			req.Tags = strings.Split(tags, ",")
+		}
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("invalid json: %w", err)
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+	}{
+		{"format query wins", "/jokes?format=xml", "application/json", "xml"},
+		{"unknown format query falls through to accept", "/jokes?format=bogus", "application/yaml", "yaml"},
+		{"accept xml", "/jokes", "application/xml", "xml"},
+		{"accept yaml variant", "/jokes", "application/x-yaml", "yaml"},
+		{"accept text", "/jokes", "text/plain", "text"},
+		{"accept star defaults to json", "/jokes", "*/*", "json"},
+		{"no accept header defaults to json", "/jokes", "", "json"},
+		{"higher q wins regardless of order", "/jokes", "application/xml;q=0.1, application/json;q=0.9", "json"},
+		{"equal q keeps first listed", "/jokes", "application/xml;q=0.8, application/json;q=0.8", "xml"},
+		{"missing q defaults to 1", "/jokes", "application/xml;q=0.5, application/json", "json"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			if got := negotiateFormat(r); got != tc.want {
+				t.Fatalf("negotiateFormat() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestJSON(t *testing.T) {
+	body := `{"content":"a joke","author":"ann","category":"dad","tags":["a","b"]}`
+	r := httptest.NewRequest(http.MethodPost, "/jokes", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	req, err := decodeRequest(r)
+	if err != nil {
+		t.Fatalf("decodeRequest: %v", err)
+	}
+	if req.Content != "a joke" || req.Author != "ann" || req.Category != "dad" {
+		t.Fatalf("decodeRequest: got %+v", req)
+	}
+	if len(req.Tags) != 2 || req.Tags[0] != "a" || req.Tags[1] != "b" {
+		t.Fatalf("decodeRequest: got tags %v", req.Tags)
+	}
+}
+
+func TestDecodeRequestXML(t *testing.T) {
+	body := `<joke><content>a joke</content><author>ann</author></joke>`
+	r := httptest.NewRequest(http.MethodPost, "/jokes", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/xml")
+
+	req, err := decodeRequest(r)
+	if err != nil {
+		t.Fatalf("decodeRequest: %v", err)
+	}
+	if req.Content != "a joke" || req.Author != "ann" {
+		t.Fatalf("decodeRequest: got %+v", req)
+	}
+}
+
+func TestDecodeRequestForm(t *testing.T) {
+	body := "content=a+joke&author=ann&tags=a,b"
+	r := httptest.NewRequest(http.MethodPost, "/jokes", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, err := decodeRequest(r)
+	if err != nil {
+		t.Fatalf("decodeRequest: %v", err)
+	}
+	if req.Content != "a joke" || req.Author != "ann" {
+		t.Fatalf("decodeRequest: got %+v", req)
+	}
+	if len(req.Tags) != 2 || req.Tags[0] != "a" || req.Tags[1] != "b" {
+		t.Fatalf("decodeRequest: got tags %v", req.Tags)
+	}
+}
+
+func TestDecodeRequestInvalidJSON(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/jokes", strings.NewReader("{not json"))
+	r.Header.Set("Content-Type", "application/json")
+
+	if _, err := decodeRequest(r); err == nil {
+		t.Fatal("decodeRequest: got nil error for invalid json, want error")
+	}
+}
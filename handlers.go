@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// api bundles the handlers that need the Store, so the chi routes in main
+// stay one-liners.
+type api struct {
+	store Store
+}
+
+// GET /jokes?offset=&limit=&category=&tag=&q= -> paginated list, or
+// full-text search over Content when q is set (see also GET /jokes/search)
+func (a *api) list(w http.ResponseWriter, r *http.Request) error {
+	filter := filterFromQuery(r)
+	var all []*Joke
+	var err error
+	if q := r.URL.Query().Get("q"); q != "" {
+		all, err = a.store.Search(r.Context(), q, filter)
+	} else {
+		offset, limit := pageParams(r)
+		all, err = a.store.GetAll(r.Context(), offset, limit, filter)
+	}
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusOK, all)
+	return nil
+}
+
+// POST /jokes { "content": "...", "author": "...", "category": "...", "tags": [...] }
+func (a *api) create(w http.ResponseWriter, r *http.Request) error {
+	req, err := decodeRequest(r)
+	if err != nil {
+		return ErrValidation{Field: "body", Msg: err.Error()}
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		return ErrValidation{Field: "content", Msg: "is required"}
+	}
+	if !validCategory(req.Category) {
+		return ErrValidation{Field: "category", Msg: "unknown category"}
+	}
+	j, err := a.store.Create(r.Context(), req.Content, req.Author, req.Category, req.Tags)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusCreated, j)
+	return nil
+}
+
+// GET /jokes/random?category=&tag=&q=
+func (a *api) random(w http.ResponseWriter, r *http.Request) error {
+	filter := filterFromQuery(r)
+	j, err := randomJoke(r.Context(), a.store, r.URL.Query().Get("q"), filter)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusOK, j)
+	return nil
+}
+
+// GET /jokes/search?q=&category=&tag=
+func (a *api) search(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		return ErrValidation{Field: "q", Msg: "is required"}
+	}
+	matches, err := a.store.Search(r.Context(), q, filterFromQuery(r))
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusOK, matches)
+	return nil
+}
+
+// GET /jokes/{id}
+func (a *api) get(w http.ResponseWriter, r *http.Request) error {
+	id, err := idFromRequest(r)
+	if err != nil {
+		return err
+	}
+	j, err := a.store.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusOK, j)
+	return nil
+}
+
+// DELETE /jokes/{id}
+func (a *api) delete(w http.ResponseWriter, r *http.Request) error {
+	id, err := idFromRequest(r)
+	if err != nil {
+		return err
+	}
+	if err := a.store.Delete(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// POST /jokes/{id}/like
+func (a *api) like(w http.ResponseWriter, r *http.Request) error {
+	id, err := idFromRequest(r)
+	if err != nil {
+		return err
+	}
+	j, err := a.store.Like(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, r, http.StatusOK, j)
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import "net/http"
+
+// GET /healthz is a liveness probe: if the process can answer at all, it's
+// alive. It never touches the store, so a wedged backend doesn't also fail
+// liveness and trigger a restart loop.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// readyzHandler probes the store so load balancers can stop sending traffic
+// to an instance whose backend is unreachable, without restarting it.
+func readyzHandler(store Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := store.Count(r.Context()); err != nil {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}
+}
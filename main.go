@@ -1,206 +1,162 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"encoding/xml"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
 	"strconv"
-	"strings"
-	"sync"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Joke represents a joke item.
 type Joke struct {
-	ID        int64     `json:"id"`
-	Content   string    `json:"content"`
-	Author    string    `json:"author,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	XMLName   xml.Name  `json:"-" xml:"joke" yaml:"-"`
+	ID        int64     `json:"id" xml:"id,attr" yaml:"id"`
+	Content   string    `json:"content" xml:"content" yaml:"content"`
+	Author    string    `json:"author,omitempty" xml:"author,omitempty" yaml:"author,omitempty"`
+	Category  string    `json:"category,omitempty" xml:"category,omitempty" yaml:"category,omitempty"`
+	Tags      []string  `json:"tags,omitempty" xml:"tags>tag,omitempty" yaml:"tags,omitempty"`
+	Likes     int64     `json:"likes" xml:"likes" yaml:"likes"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
 }
 
-// JokeStore is a threadsafe in-memory store.
-type JokeStore struct {
-	sync.RWMutex
-	jokes map[int64]*Joke
-	next  int64
-	r     *rand.Rand
+// categories lists the allowed values for Joke.Category. Empty is also
+// valid and means "uncategorized".
+var categories = map[string]bool{
+	"dad":         true,
+	"programming": true,
+	"punny":       true,
+	"dark":        true,
+	"nerd":        true,
+	"classic":     true,
 }
 
-func NewJokeStore() *JokeStore {
-	src := rand.NewSource(time.Now().UnixNano())
-	return &JokeStore{
-		jokes: make(map[int64]*Joke),
-		next:  1,
-		r:     rand.New(src),
-	}
+func validCategory(category string) bool {
+	return category == "" || categories[category]
 }
 
-func (s *JokeStore) Create(content, author string) *Joke {
-	s.Lock()
-	defer s.Unlock()
-	j := &Joke{
-		ID:        s.next,
-		Content:   strings.TrimSpace(content),
-		Author:    strings.TrimSpace(author),
-		CreatedAt: time.Now().UTC(),
+func main() {
+	storeDriver := flag.String("store", envOr("JOKE_STORE_DRIVER", "memory"), "storage backend: memory, sqlite or postgres")
+	storeDSN := flag.String("store-dsn", os.Getenv("JOKE_STORE_DSN"), "data source name for the sqlite/postgres backend")
+	flag.Parse()
+
+	store, err := NewStore(*storeDriver, *storeDSN)
+	if err != nil {
+		log.Fatalf("store: %v", err)
 	}
-	s.jokes[j.ID] = j
-	s.next++
-	return j
-}
+	defer store.Close()
 
-func (s *JokeStore) GetAll() []*Joke {
-	s.RLock()
-	defer s.RUnlock()
-	out := make([]*Joke, 0, len(s.jokes))
-	for _, j := range s.jokes {
-		out = append(out, j)
+	if *storeDriver == "" || *storeDriver == "memory" {
+		seed(store)
 	}
-	return out
-}
 
-func (s *JokeStore) Get(id int64) (*Joke, bool) {
-	s.RLock()
-	defer s.RUnlock()
-	j, ok := s.jokes[id]
-	return j, ok
+	users := NewUserStore()
+	if err := bootstrapAdmin(users); err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+	jwtSecret := []byte(os.Getenv("JOKE_JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		jwtSecret = []byte(randomToken(32))
+		log.Printf("JOKE_JWT_SECRET not set; using a generated secret, so existing tokens won't survive a restart")
+	}
+	auth := newAuthenticator(users, jwtSecret)
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go watchJokesTotal(watchCtx, store, 15*time.Second)
+
+	r := chi.NewRouter()
+	for _, mw := range defaultMiddleware() {
+		r.Use(mw)
+	}
+
+	r.Get("/healthz", healthzHandler)
+	r.Get("/readyz", readyzHandler(store))
+	r.Handle("/metrics", promhttp.Handler())
+
+	a := &api{store: store}
+	r.Group(func(jokeAPI chi.Router) {
+		jokeAPI.Use(apiRateLimiter())
+		jokeAPI.Post("/auth/token", Handle(auth.token))
+		jokeAPI.Get("/jokes", Handle(a.list))
+		jokeAPI.Post("/jokes", Handle(auth.requireRole(RoleContributor, a.create)))
+		jokeAPI.Get("/jokes/random", Handle(a.random))
+		jokeAPI.Get("/jokes/search", Handle(a.search))
+		jokeAPI.Get("/jokes/{id}", Handle(a.get))
+		jokeAPI.Delete("/jokes/{id}", Handle(auth.requireRole(RoleContributor, a.delete)))
+		jokeAPI.Post("/jokes/{id}/like", Handle(a.like))
+	})
+
+	addr := ":8081"
+	fmt.Printf("Joke API running at %s (store=%s)\n", addr, *storeDriver)
+	log.Fatal(http.ListenAndServe(addr, r))
 }
 
-func (s *JokeStore) Delete(id int64) bool {
-	s.Lock()
-	defer s.Unlock()
-	if _, ok := s.jokes[id]; !ok {
-		return false
+// filterFromQuery builds a Filter from the category/tag query params shared
+// by GET /jokes, /jokes/random and /jokes/search.
+func filterFromQuery(r *http.Request) Filter {
+	return Filter{
+		Category: r.URL.Query().Get("category"),
+		Tag:      r.URL.Query().Get("tag"),
 	}
-	delete(s.jokes, id)
-	return true
 }
 
-func (s *JokeStore) Random() (*Joke, error) {
-	s.RLock()
-	defer s.RUnlock()
-	n := len(s.jokes)
-	if n == 0 {
-		return nil, errors.New("no jokes available")
+// randomJoke picks a random joke matching filter. If q is set it ranks via
+// Search first and picks uniformly among the results, since Store.Random
+// itself doesn't know about full-text queries.
+func randomJoke(ctx context.Context, store Store, q string, filter Filter) (*Joke, error) {
+	if q == "" {
+		return store.Random(ctx, filter)
 	}
-	// collect keys
-	keys := make([]int64, 0, n)
-	for k := range s.jokes {
-		keys = append(keys, k)
+	matches, err := store.Search(ctx, q, filter)
+	if err != nil {
+		return nil, err
 	}
-	// pick random index
-	idx := s.r.Intn(n)
-	return s.jokes[keys[idx]], nil
+	if len(matches) == 0 {
+		return nil, ErrNotFound
+	}
+	return matches[rand.Intn(len(matches))], nil
 }
 
-// JSON helpers
-func writeJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
+// idFromRequest parses the :id chi URL param as a positive int64.
+func idFromRequest(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrValidation{Field: "id", Msg: "invalid id"}
+	}
+	return id, nil
 }
 
-func main() {
-	store := NewJokeStore()
-	seed(store)
-
-	mux := http.NewServeMux()
-
-	// GET /jokes -> list
-	// POST /jokes -> create { "content": "...", "author": "..." }
-	mux.HandleFunc("/jokes", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			all := store.GetAll()
-			writeJSON(w, http.StatusOK, all)
-		case http.MethodPost:
-			var req struct {
-				Content string `json:"content"`
-				Author  string `json:"author,omitempty"`
-			}
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				http.Error(w, "invalid json", http.StatusBadRequest)
-				return
-			}
-			req.Content = strings.TrimSpace(req.Content)
-			if req.Content == "" {
-				http.Error(w, "content is required", http.StatusBadRequest)
-				return
-			}
-			j := store.Create(req.Content, req.Author)
-			writeJSON(w, http.StatusCreated, j)
-		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	// GET /jokes/random -> random joke
-	mux.HandleFunc("/jokes/random", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		j, err := store.Random()
-		if err != nil {
-			http.Error(w, "no jokes available", http.StatusNotFound)
-			return
-		}
-		writeJSON(w, http.StatusOK, j)
-	})
-
-	// GET /jokes/{id}  DELETE /jokes/{id}
-	mux.HandleFunc("/jokes/", func(w http.ResponseWriter, r *http.Request) {
-		// trim trailing slash and split
-		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-		if len(parts) != 2 {
-			http.Error(w, "invalid path", http.StatusBadRequest)
-			return
-		}
-		id, err := strconv.ParseInt(parts[1], 10, 64)
-		if err != nil || id <= 0 {
-			http.Error(w, "invalid id", http.StatusBadRequest)
-			return
-		}
-		switch r.Method {
-		case http.MethodGet:
-			j, ok := store.Get(id)
-			if !ok {
-				http.Error(w, "not found", http.StatusNotFound)
-				return
-			}
-			writeJSON(w, http.StatusOK, j)
-		case http.MethodDelete:
-			ok := store.Delete(id)
-			if !ok {
-				http.Error(w, "not found", http.StatusNotFound)
-				return
-			}
-			w.WriteHeader(http.StatusNoContent)
-		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	addr := ":8081"
-	fmt.Printf("Joke API running at %s\n", addr)
-	log.Fatal(http.ListenAndServe(addr, loggingMiddleware(mux)))
+// pageParams reads offset/limit query params, defaulting to no pagination.
+func pageParams(r *http.Request) (offset, limit int) {
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v > 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	return offset, limit
 }
 
-// simple logging middleware
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
-	})
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
 
 // seed with a few jokes so /jokes/random works immediately
-func seed(s *JokeStore) {
-	s.Create("I told my computer I needed a break, and it said: 'No problem â€” I'll go to sleep.'", "unknown")
-	s.Create("Why do programmers prefer dark mode? Because light attracts bugs.", "classic")
-	s.Create("There's no place like 127.0.0.1", "nerd")
+func seed(s Store) {
+	ctx := context.Background()
+	s.Create(ctx, "I told my computer I needed a break, and it said: 'No problem â€” I'll go to sleep.'", "unknown", "programming", []string{"computers", "puns"})
+	s.Create(ctx, "Why do programmers prefer dark mode? Because light attracts bugs.", "classic", "programming", []string{"bugs", "dark mode"})
+	s.Create(ctx, "There's no place like 127.0.0.1", "nerd", "nerd", []string{"networking"})
 }
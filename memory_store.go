@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MemoryStore is a threadsafe in-memory Store. It loses all data on
+// restart; use the sqlite or postgres driver for anything persistent.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	jokes map[int64]*Joke
+	next  int64
+	r     *rand.Rand
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	src := rand.NewSource(time.Now().UnixNano())
+	return &MemoryStore{
+		jokes: make(map[int64]*Joke),
+		next:  1,
+		r:     rand.New(src),
+	}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, content, author, category string, tags []string) (*Joke, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := &Joke{
+		ID:        s.next,
+		Content:   strings.TrimSpace(content),
+		Author:    strings.TrimSpace(author),
+		Category:  category,
+		Tags:      normalizeTags(tags),
+		CreatedAt: time.Now().UTC(),
+	}
+	s.jokes[j.ID] = j
+	s.next++
+	return j, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id int64) (*Joke, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	j, ok := s.jokes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return j, nil
+}
+
+// GetAll returns jokes ordered by id, newest first, after offset/limit and
+// filter are applied. limit <= 0 means no limit.
+func (s *MemoryStore) GetAll(ctx context.Context, offset, limit int, filter Filter) ([]*Joke, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := s.filtered(filter)
+	sortByIDDesc(matched)
+
+	if offset >= len(matched) {
+		return []*Joke{}, nil
+	}
+	matched = matched[offset:]
+	if limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jokes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.jokes, id)
+	return nil
+}
+
+func (s *MemoryStore) Like(ctx context.Context, id int64) (*Joke, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jokes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	j.Likes++
+	return j, nil
+}
+
+func (s *MemoryStore) Random(ctx context.Context, filter Filter) (*Joke, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := s.filtered(filter)
+	if len(matched) == 0 {
+		return nil, ErrNotFound
+	}
+	return matched[s.r.Intn(len(matched))], nil
+}
+
+// Search tokenizes query into words and ranks matches by how many distinct
+// query tokens appear in the joke's content, breaking ties newest-first. The
+// SQL-backed stores do the equivalent with their own full-text index.
+func (s *MemoryStore) Search(ctx context.Context, query string, filter Filter) ([]*Joke, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := tokenize(query)
+	matched := s.filtered(filter)
+	if len(tokens) == 0 {
+		sortByIDDesc(matched)
+		return matched, nil
+	}
+	querySet := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		querySet[t] = true
+	}
+
+	type scored struct {
+		joke  *Joke
+		score int
+	}
+	var hits []scored
+	for _, j := range matched {
+		content := tokenize(j.Content)
+		contentSet := make(map[string]bool, len(content))
+		for _, t := range content {
+			contentSet[t] = true
+		}
+		score := 0
+		for t := range querySet {
+			if contentSet[t] {
+				score++
+			}
+		}
+		if score > 0 {
+			hits = append(hits, scored{joke: j, score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].joke.ID > hits[j].joke.ID
+	})
+	out := make([]*Joke, len(hits))
+	for i, h := range hits {
+		out[i] = h.joke
+	}
+	return out, nil
+}
+
+// tokenize lowercases s and splits it into words, dropping punctuation.
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return fields
+}
+
+func (s *MemoryStore) Count(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.jokes)), nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+// filtered returns a copy of the jokes matching filter; caller must hold s.mu.
+func (s *MemoryStore) filtered(filter Filter) []*Joke {
+	out := make([]*Joke, 0, len(s.jokes))
+	for _, j := range s.jokes {
+		if filter.Author != "" && !strings.EqualFold(j.Author, filter.Author) {
+			continue
+		}
+		if filter.Category != "" && !strings.EqualFold(j.Category, filter.Category) {
+			continue
+		}
+		if filter.Tag != "" && !hasTag(j.Tags, filter.Tag) {
+			continue
+		}
+		out = append(out, j)
+	}
+	return out
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortByIDDesc(jokes []*Joke) {
+	sort.Slice(jokes, func(i, j int) bool { return jokes[i].ID > jokes[j].ID })
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests, labeled by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, labeled by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	jokesTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "jokes_total",
+		Help: "Number of jokes currently in the store.",
+	})
+)
+
+// metricsMiddleware records http_requests_total and
+// http_request_duration_seconds for every request. It labels by the matched
+// chi route pattern (e.g. "/jokes/{id}") rather than the raw path, so
+// per-id routes don't explode into one series per id.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestsTotal.WithLabelValues(r.Method, route, strconv.Itoa(sw.status)).Inc()
+		httpRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter captures the status code written so metricsMiddleware can
+// label by it; http.ResponseWriter doesn't expose what was sent afterward.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// watchJokesTotal keeps the jokes_total gauge in sync with store.Count,
+// polling on interval until ctx is done.
+func watchJokesTotal(ctx context.Context, store Store, interval time.Duration) {
+	refreshJokesTotal(ctx, store)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshJokesTotal(ctx, store)
+		}
+	}
+}
+
+func refreshJokesTotal(ctx context.Context, store Store) {
+	n, err := store.Count(ctx)
+	if err != nil {
+		log.Printf("metrics: refresh jokes_total: %v", err)
+		return
+	}
+	jokesTotal.Set(float64(n))
+}
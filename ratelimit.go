@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long a per-caller limiter can sit unused before
+// evictIdle reclaims it. Without this, every distinct IP or bearer token
+// that ever made a request would keep its *rate.Limiter in memory forever.
+const idleLimiterTTL = 10 * time.Minute
+
+// rateLimiter enforces a token-bucket limit per caller. Requests carrying an
+// Authorization header (writes, which require auth) are limited per
+// credential; anonymous requests (reads) are limited per IP.
+type rateLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*limiterEntry
+	anonLimit rate.Limit
+	anonBurst int
+	authLimit rate.Limit
+	authBurst int
+}
+
+// limiterEntry pairs a caller's limiter with when it was last used, so
+// evictIdle knows what's safe to reclaim.
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(anonLimit, authLimit rate.Limit, anonBurst, authBurst int) *rateLimiter {
+	rl := &rateLimiter{
+		limiters:  make(map[string]*limiterEntry),
+		anonLimit: anonLimit,
+		anonBurst: anonBurst,
+		authLimit: authLimit,
+		authBurst: authBurst,
+	}
+	go rl.evictIdle()
+	return rl
+}
+
+func (rl *rateLimiter) limiterFor(key string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	entry, ok := rl.limiters[key]
+	if !ok {
+		var l *rate.Limiter
+		if strings.HasPrefix(key, "auth:") {
+			l = rate.NewLimiter(rl.authLimit, rl.authBurst)
+		} else {
+			l = rate.NewLimiter(rl.anonLimit, rl.anonBurst)
+		}
+		entry = &limiterEntry{limiter: l}
+		rl.limiters[key] = entry
+	}
+	entry.lastSeen = time.Now()
+	return entry.limiter
+}
+
+// evictIdle periodically reclaims limiters that haven't been used in
+// idleLimiterTTL, so the map doesn't grow without bound on a public
+// endpoint. It runs for the lifetime of the process.
+func (rl *rateLimiter) evictIdle() {
+	ticker := time.NewTicker(idleLimiterTTL)
+	defer ticker.Stop()
+	for range ticker.C {
+		rl.sweep(time.Now())
+	}
+}
+
+// sweep deletes entries idle since before now-idleLimiterTTL.
+func (rl *rateLimiter) sweep(now time.Time) {
+	cutoff := now.Add(-idleLimiterTTL)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for key, entry := range rl.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(rl.limiters, key)
+		}
+	}
+}
+
+// middleware rejects requests over the limit with 429 and a Retry-After
+// header instead of letting them queue.
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := rl.limiterFor(rateLimitKey(r))
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); !reservation.OK() || delay > 0 {
+			reservation.Cancel()
+			w.Header().Set("Retry-After", fmt.Sprintf("%.0f", delay.Seconds()))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey keys by the caller's credential when present, since writes
+// sit behind auth, and falls back to remote IP for anonymous reads.
+func rateLimitKey(r *http.Request) string {
+	if auth := strings.TrimSpace(r.Header.Get("Authorization")); auth != "" {
+		return "auth:" + strings.TrimPrefix(auth, "Bearer ")
+	}
+	return "ip:" + clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestRateLimiterSweepEvictsIdle is a regression test for an unbounded
+// memory leak: limiterFor never removed entries, so every distinct caller
+// kept a *rate.Limiter forever. sweep should reclaim ones idle past
+// idleLimiterTTL and leave recently used ones alone.
+func TestRateLimiterSweepEvictsIdle(t *testing.T) {
+	rl := &rateLimiter{
+		limiters:  make(map[string]*limiterEntry),
+		anonLimit: rate.Limit(5),
+		anonBurst: 10,
+		authLimit: rate.Limit(2),
+		authBurst: 5,
+	}
+
+	rl.limiterFor("ip:1.2.3.4")
+	rl.limiterFor("ip:5.6.7.8")
+
+	now := time.Now()
+	rl.mu.Lock()
+	rl.limiters["ip:1.2.3.4"].lastSeen = now.Add(-2 * idleLimiterTTL)
+	rl.mu.Unlock()
+
+	rl.sweep(now)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if _, ok := rl.limiters["ip:1.2.3.4"]; ok {
+		t.Fatal("sweep did not evict an idle entry")
+	}
+	if _, ok := rl.limiters["ip:5.6.7.8"]; !ok {
+		t.Fatal("sweep evicted a recently used entry")
+	}
+}
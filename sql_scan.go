@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// scanner is satisfied by *sql.Row and *sql.Rows, letting the SQL-backed
+// stores share a single row layout.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// jokeColumns lists the columns every SELECT against the jokes table reads,
+// in order, so scanJoke/scanJokes stay in sync with the query text.
+const jokeColumns = "id, content, author, category, tags, likes, created_at"
+
+func scanJoke(row scanner) (*Joke, error) {
+	var j Joke
+	var tags string
+	if err := row.Scan(&j.ID, &j.Content, &j.Author, &j.Category, &tags, &j.Likes, &j.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan joke: %w", err)
+	}
+	j.Tags = splitTags(tags)
+	return &j, nil
+}
+
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func joinTags(tags []string) string {
+	return strings.Join(normalizeTags(tags), ",")
+}
+
+func scanJokes(rows *sql.Rows) ([]*Joke, error) {
+	out := make([]*Joke, 0)
+	for rows.Next() {
+		j, err := scanJoke(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan jokes: %w", err)
+	}
+	return out, nil
+}
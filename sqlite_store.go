@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS jokes (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	content    TEXT NOT NULL,
+	author     TEXT NOT NULL DEFAULT '',
+	category   TEXT NOT NULL DEFAULT '',
+	tags       TEXT NOT NULL DEFAULT '',
+	likes      INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_jokes_author     ON jokes (author);
+CREATE INDEX IF NOT EXISTS idx_jokes_category   ON jokes (category);
+CREATE INDEX IF NOT EXISTS idx_jokes_created_at ON jokes (created_at);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS jokes_fts USING fts5(
+	content, content='jokes', content_rowid='id'
+);
+
+CREATE TRIGGER IF NOT EXISTS jokes_ai AFTER INSERT ON jokes BEGIN
+	INSERT INTO jokes_fts(rowid, content) VALUES (new.id, new.content);
+END;
+CREATE TRIGGER IF NOT EXISTS jokes_ad AFTER DELETE ON jokes BEGIN
+	INSERT INTO jokes_fts(jokes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+`
+
+// SQLiteStore persists jokes to a SQLite database via modernc.org/sqlite
+// (pure Go, no cgo). dsn is a file path, or ":memory:" for a throwaway db.
+// Content is mirrored into an FTS5 virtual table so Search gets real
+// full-text ranking instead of a LIKE scan.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) a SQLite database at dsn and
+// runs its migration.
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	if dsn == "" {
+		dsn = "jokes.db"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: open: %w", err)
+	}
+	// sqlite only tolerates a single writer at a time.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: migrate: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Create(ctx context.Context, content, author, category string, tags []string) (*Joke, error) {
+	j := &Joke{
+		Content:   strings.TrimSpace(content),
+		Author:    strings.TrimSpace(author),
+		Category:  category,
+		Tags:      normalizeTags(tags),
+		CreatedAt: time.Now().UTC(),
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO jokes (content, author, category, tags, created_at) VALUES (?, ?, ?, ?, ?)`,
+		j.Content, j.Author, j.Category, joinTags(j.Tags), j.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: create: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: create: %w", err)
+	}
+	j.ID = id
+	return j, nil
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id int64) (*Joke, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+jokeColumns+` FROM jokes WHERE id = ?`, id)
+	return scanJoke(row)
+}
+
+func (s *SQLiteStore) GetAll(ctx context.Context, offset, limit int, filter Filter) ([]*Joke, error) {
+	where, args := filter.sqliteWhere()
+	query := `SELECT ` + jokeColumns + ` FROM jokes ` + where + ` ORDER BY id DESC`
+	if limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	} else if offset > 0 {
+		query += ` LIMIT -1 OFFSET ?`
+		args = append(args, offset)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: getall: %w", err)
+	}
+	defer rows.Close()
+	return scanJokes(rows)
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM jokes WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("sqlite: delete: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite: delete: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Like(ctx context.Context, id int64) (*Joke, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE jokes SET likes = likes + 1 WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: like: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: like: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *SQLiteStore) Random(ctx context.Context, filter Filter) (*Joke, error) {
+	where, args := filter.sqliteWhere()
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+jokeColumns+` FROM jokes `+where+` ORDER BY RANDOM() LIMIT 1`, args...)
+	return scanJoke(row)
+}
+
+// Search ranks jokes by FTS5 relevance (bm25) against query, then applies
+// author/category/tag filters on the result. query is tokenized the same
+// way as MemoryStore.Search and each token quoted before reaching MATCH, so
+// FTS5 query syntax in user input (AND/OR/NOT, *, -, (), unbalanced quotes,
+// ...) can't reach the query parser and turn into a 500.
+func (s *SQLiteStore) Search(ctx context.Context, query string, filter Filter) ([]*Joke, error) {
+	matchQuery := ftsQuery(query)
+	if matchQuery == "" {
+		return s.GetAll(ctx, 0, 0, filter)
+	}
+
+	where, args := filter.sqliteWhereWithAlias("j")
+	clause := "jokes_fts MATCH ?"
+	if where == "" {
+		where = "WHERE " + clause
+	} else {
+		where += " AND " + clause
+	}
+	args = append(args, matchQuery)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT j.id, j.content, j.author, j.category, j.tags, j.likes, j.created_at
+		 FROM jokes_fts
+		 JOIN jokes j ON j.id = jokes_fts.rowid
+		 `+where+`
+		 ORDER BY bm25(jokes_fts)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: search: %w", err)
+	}
+	defer rows.Close()
+	return scanJokes(rows)
+}
+
+// ftsQuery builds a safe FTS5 MATCH expression out of a raw user query:
+// tokenize it like MemoryStore does and double-quote each token so it's
+// treated as a literal string rather than FTS5 query syntax, OR-ing them
+// together to match MemoryStore's any-token-matches semantics. Returns ""
+// if query has no usable tokens.
+func ftsQuery(query string) string {
+	tokens := tokenize(query)
+	if len(tokens) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(tokens))
+	for i, t := range tokens {
+		quoted[i] = `"` + t + `"`
+	}
+	return strings.Join(quoted, " OR ")
+}
+
+func (s *SQLiteStore) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM jokes`).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: count: %w", err)
+	}
+	return n, nil
+}
+
+func (s *SQLiteStore) Close() error { return s.db.Close() }
+
+// sqliteWhere renders filter as a SQL WHERE clause using ? placeholders.
+func (f Filter) sqliteWhere() (string, []any) {
+	return f.sqliteWhereWithAlias("")
+}
+
+// sqliteWhereWithAlias is sqliteWhere but qualifies columns with alias.,
+// needed once Search joins jokes_fts against jokes j.
+func (f Filter) sqliteWhereWithAlias(alias string) (string, []any) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	var clauses []string
+	var args []any
+	if f.Author != "" {
+		clauses = append(clauses, prefix+"author = ?")
+		args = append(args, f.Author)
+	}
+	if f.Category != "" {
+		clauses = append(clauses, prefix+"category = ?")
+		args = append(args, f.Category)
+	}
+	if f.Tag != "" {
+		clauses = append(clauses, "(',' || "+prefix+"tags || ',') LIKE ?")
+		args = append(args, "%,"+f.Tag+",%")
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
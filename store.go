@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned by Store implementations when a joke id has no match.
+var ErrNotFound = errors.New("joke not found")
+
+// Filter narrows the results returned by GetAll, Random and Search.
+type Filter struct {
+	Author   string
+	Category string
+	Tag      string
+}
+
+// Store is implemented by every joke storage backend (in-memory, SQLite,
+// Postgres, ...). Handlers depend only on this interface so the backend can
+// be swapped with the --store flag without touching main.go.
+type Store interface {
+	Create(ctx context.Context, content, author, category string, tags []string) (*Joke, error)
+	Get(ctx context.Context, id int64) (*Joke, error)
+	GetAll(ctx context.Context, offset, limit int, filter Filter) ([]*Joke, error)
+	Delete(ctx context.Context, id int64) error
+	Like(ctx context.Context, id int64) (*Joke, error)
+	Random(ctx context.Context, filter Filter) (*Joke, error)
+	Search(ctx context.Context, query string, filter Filter) ([]*Joke, error)
+	Count(ctx context.Context) (int64, error)
+	Close() error
+}
+
+// normalizeTags trims, lowercases and deduplicates tags, dropping empty
+// entries, so the same tag always compares equal regardless of how a client
+// cased or spaced it.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NewStore builds a Store from a driver name and DSN, as selected by the
+// --store flag or JOKE_STORE_DSN env var. driver is one of "memory",
+// "sqlite" or "postgres".
+func NewStore(driver, dsn string) (Store, error) {
+	switch driver {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "sqlite":
+		return NewSQLiteStore(dsn)
+	case "postgres":
+		return NewPostgresStore(dsn)
+	default:
+		return nil, errors.New("store: unknown driver " + driver)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// newStores returns one instance of every Store backend that can run without
+// an external service, so behavior tests can run the same cases against each.
+func newStores(t *testing.T) map[string]Store {
+	t.Helper()
+	sqliteStore, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { sqliteStore.Close() })
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestStoreCreateGetDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			j, err := store.Create(ctx, " why did the chicken cross the road? ", " Anon ", "classic", []string{"Chicken", "chicken", ""})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if j.Content != "why did the chicken cross the road?" || j.Author != "Anon" {
+				t.Fatalf("Create did not trim content/author: %+v", j)
+			}
+			if len(j.Tags) != 1 || j.Tags[0] != "chicken" {
+				t.Fatalf("Create did not normalize tags: %+v", j.Tags)
+			}
+
+			got, err := store.Get(ctx, j.ID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.Content != j.Content {
+				t.Fatalf("Get returned %+v, want %+v", got, j)
+			}
+
+			if err := store.Delete(ctx, j.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := store.Get(ctx, j.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get after Delete: got err %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, j.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Delete of missing id: got err %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreLike(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			j, err := store.Create(ctx, "a joke", "", "", nil)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			for i := 1; i <= 2; i++ {
+				liked, err := store.Like(ctx, j.ID)
+				if err != nil {
+					t.Fatalf("Like: %v", err)
+				}
+				if liked.Likes != int64(i) {
+					t.Fatalf("Like #%d: got %d likes, want %d", i, liked.Likes, i)
+				}
+			}
+			if _, err := store.Like(ctx, j.ID+1000); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Like of missing id: got err %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreGetAllFilterAndPaginate(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			mustCreate(t, store, "dad joke one", "ann", "dad", []string{"family"})
+			mustCreate(t, store, "dad joke two", "bob", "dad", []string{"family"})
+			mustCreate(t, store, "nerd joke", "ann", "nerd", []string{"tech"})
+
+			dadJokes, err := store.GetAll(ctx, 0, 0, Filter{Category: "dad"})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if len(dadJokes) != 2 {
+				t.Fatalf("GetAll(category=dad): got %d jokes, want 2", len(dadJokes))
+			}
+
+			annJokes, err := store.GetAll(ctx, 0, 0, Filter{Author: "ann"})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if len(annJokes) != 2 {
+				t.Fatalf("GetAll(author=ann): got %d jokes, want 2", len(annJokes))
+			}
+
+			page, err := store.GetAll(ctx, 1, 1, Filter{})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if len(page) != 1 {
+				t.Fatalf("GetAll(offset=1, limit=1): got %d jokes, want 1", len(page))
+			}
+		})
+	}
+}
+
+// TestStoreSearchWithFilter is a regression test for a bug where
+// SQLiteStore.Search bound its filter and FTS MATCH arguments to the wrong
+// placeholders whenever a filter was combined with a query, matching the
+// filter value against content and the query against the filtered column.
+func TestStoreSearchWithFilter(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			mustCreate(t, store, "why did the computer go to therapy", "ann", "programming", nil)
+			mustCreate(t, store, "the computer says no", "ann", "dark", nil)
+
+			results, err := store.Search(ctx, "computer", Filter{Category: "programming"})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(results) != 1 || results[0].Category != "programming" {
+				t.Fatalf("Search(q=computer, category=programming): got %+v, want exactly the programming joke", results)
+			}
+
+			none, err := store.Search(ctx, "computer", Filter{Category: "nerd"})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(none) != 0 {
+				t.Fatalf("Search(q=computer, category=nerd): got %d results, want 0", len(none))
+			}
+		})
+	}
+}
+
+// TestStoreSearchQuerySyntaxCharacters is a regression test for a bug where
+// SQLiteStore.Search passed the raw query straight to FTS5's MATCH, so
+// queries containing FTS5 query syntax (AND/OR/NOT, *, -, (), unbalanced
+// quotes, ...) returned a SQL error instead of a result set.
+func TestStoreSearchQuerySyntaxCharacters(t *testing.T) {
+	ctx := context.Background()
+	queries := []string{"c++", "computer-", `unbalanced "quote`, "OR", "foo*bar("}
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			mustCreate(t, store, "a computer joke", "", "", nil)
+			for _, q := range queries {
+				if _, err := store.Search(ctx, q, Filter{}); err != nil {
+					t.Fatalf("Search(%q): %v", q, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreCount(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			mustCreate(t, store, "one", "", "", nil)
+			mustCreate(t, store, "two", "", "", nil)
+
+			n, err := store.Count(ctx)
+			if err != nil {
+				t.Fatalf("Count: %v", err)
+			}
+			if n != 2 {
+				t.Fatalf("Count: got %d, want 2", n)
+			}
+		})
+	}
+}
+
+func mustCreate(t *testing.T, store Store, content, author, category string, tags []string) *Joke {
+	t.Helper()
+	j, err := store.Create(context.Background(), content, author, category, tags)
+	if err != nil {
+		t.Fatalf("Create(%q): %v", content, err)
+	}
+	return j
+}